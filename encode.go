@@ -0,0 +1,110 @@
+package urlbuilder
+
+import (
+	"fmt"
+)
+
+// hexDigits are the uppercase hex digits used to render a percent-encoded
+// byte, matching the %02X format net/url and earlier versions of this
+// package used.
+const hexDigits = "0123456789ABCDEF"
+
+// appendEncodedPathSegment appends segment's percent-encoded form to dst
+// per RFC 3986's pchar production (unreserved / sub-delims / ":" / "@" /
+// pct-encoded), directly into dst with no intermediate string allocation.
+// A segment may itself contain internal slashes, since Path appends whole
+// sub-paths like "/a/b" as a single entry, so encoding is applied between
+// "/" separators rather than to the segment as a whole.
+func appendEncodedPathSegment(dst []byte, segment string) []byte {
+	start := 0
+	for i := 0; i <= len(segment); i++ {
+		if i < len(segment) && segment[i] != '/' {
+			continue
+		}
+		dst = appendEncodedPchar(dst, segment[start:i])
+		if i < len(segment) {
+			dst = append(dst, '/')
+		}
+		start = i + 1
+	}
+	return dst
+}
+
+// appendEncodedPchar appends s to dst, percent-encoding every byte that is
+// not a valid pchar (RFC 3986 section 3.3): unreserved characters,
+// sub-delims, ":" and "@" pass through unescaped; everything else,
+// including spaces, "#", "?" and non-ASCII bytes, is percent-encoded. A
+// "%" that already starts a valid pct-encoded triplet (e.g. "%2F") is left
+// as-is instead of having its "%" re-encoded, so callers that escape a
+// value up front — such as PathTemplate, which must escape a "/" inside a
+// placeholder value before splicing it into the template — don't get it
+// mangled by this second pass.
+func appendEncodedPchar(dst []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '%' && i+2 < len(s) && isHexDigit(s[i+1]) && isHexDigit(s[i+2]) {
+			dst = append(dst, c, s[i+1], s[i+2])
+			i += 2
+			continue
+		}
+		if isPchar(c) {
+			dst = append(dst, c)
+		} else {
+			dst = append(dst, '%', hexDigits[c>>4], hexDigits[c&0x0F])
+		}
+	}
+	return dst
+}
+
+// encodePchar is the string-returning counterpart to appendEncodedPchar,
+// for callers such as PathTemplate that need an escaped value to splice
+// into a larger string rather than append to a []byte in progress.
+func encodePchar(s string) string {
+	return string(appendEncodedPchar(make([]byte, 0, len(s)), s))
+}
+
+func isPchar(c byte) bool {
+	return isUnreserved(c) || isSubDelim(c) || c == ':' || c == '@'
+}
+
+func isHexDigit(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
+
+func isUnreserved(c byte) bool {
+	return ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isSubDelim(c byte) bool {
+	switch c {
+	case '!', '$', '&', '\'', '(', ')', '*', '+', ',', ';', '=':
+		return true
+	}
+	return false
+}
+
+// dedupeQueryLast collapses q to one entry per name, keeping each name's
+// first-seen position but its last-seen value. This replaces the old
+// map[string]string iteration QModeLast used to build its output, which
+// produced a different, randomized order on every call. If errorOnDup is
+// true, a second occurrence of any name is reported as an error instead.
+func dedupeQueryLast(q []query, errorOnDup bool) ([]query, error) {
+	order := make([]string, 0, len(q))
+	last := make(map[string]string, len(q))
+	seen := make(map[string]bool, len(q))
+	for _, kv := range q {
+		if !seen[kv.name] {
+			seen[kv.name] = true
+			order = append(order, kv.name)
+		} else if errorOnDup {
+			return nil, fmt.Errorf("duplicate query name found")
+		}
+		last[kv.name] = kv.value
+	}
+	out := make([]query, len(order))
+	for i, name := range order {
+		out[i] = query{name: name, value: last[name]}
+	}
+	return out, nil
+}
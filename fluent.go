@@ -0,0 +1,127 @@
+package urlbuilder
+
+import "fmt"
+
+// This file exposes a fluent, receiver-style counterpart to each UrlPart
+// option, so a URL can be assembled as New().Path("x").Query("k", "v").ID(1)
+// instead of passing every part to New/Clone up front. Each method composes
+// with its UrlPart twin via applyPart, so validation and error
+// short-circuiting behave identically whichever style is used.
+
+// Sch sets ub's scheme and returns ub for chaining.
+func (ub *UrlBuilder) Sch(sch string) *UrlBuilder {
+	ub.applyPart(Sch(sch))
+	return ub
+}
+
+// Host sets ub's host (and any scheme, userinfo, port, path, query or
+// fragment embedded in h) and returns ub for chaining.
+func (ub *UrlBuilder) Host(h string) *UrlBuilder {
+	ub.applyPart(Host(h))
+	return ub
+}
+
+// Usr sets ub's username and returns ub for chaining.
+func (ub *UrlBuilder) Usr(u string) *UrlBuilder {
+	ub.applyPart(Usr(u))
+	return ub
+}
+
+// Pwd sets ub's password and returns ub for chaining.
+func (ub *UrlBuilder) Pwd(p string) *UrlBuilder {
+	ub.applyPart(Pwd(p))
+	return ub
+}
+
+// UsrPwd sets ub's username and password and returns ub for chaining.
+func (ub *UrlBuilder) UsrPwd(usr, pwd string) *UrlBuilder {
+	ub.applyPart(UsrPwd(usr, pwd))
+	return ub
+}
+
+// Path appends a path segment to ub and returns ub for chaining.
+func (ub *UrlBuilder) Path(path string) *UrlBuilder {
+	ub.applyPart(Path(path))
+	return ub
+}
+
+// ID sets ub's trailing ID segment and returns ub for chaining.
+func (ub *UrlBuilder) ID(id any) *UrlBuilder {
+	ub.applyPart(ID(id))
+	return ub
+}
+
+// Port sets ub's port and returns ub for chaining.
+func (ub *UrlBuilder) Port(port uint) *UrlBuilder {
+	ub.applyPart(Port(port))
+	return ub
+}
+
+// Mode sets ub's query deduplication mode and returns ub for chaining.
+func (ub *UrlBuilder) Mode(mode QueryMode) *UrlBuilder {
+	ub.applyPart(Mode(mode))
+	return ub
+}
+
+// Query appends a query parameter to ub and returns ub for chaining. See
+// the package-level Query for the accepted value types.
+func (ub *UrlBuilder) Query(name string, value any) *UrlBuilder {
+	ub.applyPart(Query(name, value))
+	return ub
+}
+
+// QuerySet sets name to value on ub, replacing any existing entries for
+// name, and returns ub for chaining.
+func (ub *UrlBuilder) QuerySet(name string, value any) *UrlBuilder {
+	ub.applyPart(QuerySet(name, value))
+	return ub
+}
+
+// QueryAdd appends value to name's existing entries on ub and returns ub
+// for chaining.
+func (ub *UrlBuilder) QueryAdd(name string, value any) *UrlBuilder {
+	ub.applyPart(QueryAdd(name, value))
+	return ub
+}
+
+// QueryDel removes every entry named name from ub and returns ub for
+// chaining.
+func (ub *UrlBuilder) QueryDel(name string) *UrlBuilder {
+	ub.applyPart(QueryDel(name))
+	return ub
+}
+
+// Frag sets ub's fragment and returns ub for chaining.
+func (ub *UrlBuilder) Frag(f string) *UrlBuilder {
+	ub.applyPart(Frag(f))
+	return ub
+}
+
+// PathTemplate expands pattern against vars and appends it as a path
+// segment on ub, returning ub for chaining. See the package-level
+// PathTemplate for the placeholder syntax.
+func (ub *UrlBuilder) PathTemplate(pattern string, vars map[string]any) *UrlBuilder {
+	ub.applyPart(PathTemplate(pattern, vars))
+	return ub
+}
+
+// Validate checks ub's current state for structural problems that Build
+// would otherwise build into a malformed URL silently: an invalid scheme,
+// an out-of-range port, or userinfo set without a host. The first problem
+// found is recorded in ub.err, so a chained BuildSafe reports it as an
+// error. It returns ub so it composes with the other fluent setters, e.g.
+// New(...).Validate().BuildSafe().
+func (ub *UrlBuilder) Validate() *UrlBuilder {
+	if ub.err != nil {
+		return ub
+	}
+	switch {
+	case ub.scheme != "" && !validSchemeChars(ub.scheme):
+		ub.err = fmt.Errorf("urlbuilder: invalid scheme %q", ub.scheme)
+	case ub.port > 65535:
+		ub.err = fmt.Errorf("urlbuilder: port %d exceeds maximum of 65535", ub.port)
+	case ub.user != "" && ub.host == "":
+		ub.err = fmt.Errorf("urlbuilder: host is required when user is set")
+	}
+	return ub
+}
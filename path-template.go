@@ -0,0 +1,152 @@
+package urlbuilder
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// placeholder is one {name} or {name:constraint} span found in a path
+// template, along with its byte offsets in the original pattern string.
+type placeholder struct {
+	name       string
+	constraint string
+	start, end int
+}
+
+// findPlaceholders scans pattern for {name} / {name:regex} placeholders,
+// tracking brace depth inside the constraint the way gorilla/mux's own
+// route parser does, so a constraint that itself contains braces (e.g. a
+// regex quantifier like "[0-9]{3}") doesn't end the placeholder early. An
+// unterminated "{" is left as literal text.
+func findPlaceholders(pattern string) []placeholder {
+	var out []placeholder
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '{' {
+			continue
+		}
+		start := i
+		depth := 1
+		j := i + 1
+		for ; j < len(pattern) && depth > 0; j++ {
+			switch pattern[j] {
+			case '{':
+				depth++
+			case '}':
+				depth--
+			}
+		}
+		if depth != 0 {
+			break
+		}
+
+		inner := pattern[start+1 : j-1]
+		name, constraint := inner, ""
+		if idx := strings.IndexByte(inner, ':'); idx != -1 {
+			name, constraint = inner[:idx], inner[idx+1:]
+		}
+		out = append(out, placeholder{name: name, constraint: constraint, start: start, end: j})
+		i = j - 1
+	}
+	return out
+}
+
+// PathTemplate substitutes vars into pattern and appends the result as a
+// path segment, in the style of gorilla/mux route templates, e.g.
+// "/users/{userID}/orders/{orderID:[0-9]+}". The substituted segment is
+// percent-encoded the same way Path's segments are when Build runs. It
+// returns an error if a placeholder has no matching entry in vars, or if
+// the value fails the placeholder's inline regex constraint.
+func PathTemplate(pattern string, vars map[string]any) UrlPart {
+	return func(ub *UrlBuilder) error {
+		segment, err := expandPathTemplate(pattern, vars)
+		if err != nil {
+			return err
+		}
+		if segment == "" {
+			return nil
+		}
+		ub.path = append(ub.path, segment)
+		return nil
+	}
+}
+
+// PathTemplateString is the typed counterpart to PathTemplate for callers
+// that already have their placeholder values as strings. It copies vars
+// into a map[string]any and delegates to PathTemplate, so each value still
+// goes through the same fmt.Sprint conversion.
+func PathTemplateString(pattern string, vars map[string]string) UrlPart {
+	anyVars := make(map[string]any, len(vars))
+	for k, v := range vars {
+		anyVars[k] = v
+	}
+	return PathTemplate(pattern, anyVars)
+}
+
+// MustPathTemplate is like PathTemplate but panics instead of returning an
+// error, for call sites building a URL from a template that is known to
+// be valid and fully filled, such as a compile-time route constant.
+func MustPathTemplate(pattern string, vars map[string]any) UrlPart {
+	return func(ub *UrlBuilder) error {
+		segment, err := expandPathTemplate(pattern, vars)
+		if err != nil {
+			panic(err)
+		}
+		if segment == "" {
+			return nil
+		}
+		ub.path = append(ub.path, segment)
+		return nil
+	}
+}
+
+// expandPathTemplate resolves every {name} / {name:regex} placeholder in
+// pattern against vars. Literal (non-placeholder) text is passed through
+// unchanged; it is percent-encoded later, the same way any other Path
+// segment is when Build assembles the final URL. Each substituted value
+// is percent-encoded here, before splicing, so a value containing "/"
+// becomes a literal "%2F" instead of an unintended extra path segment —
+// Build's later per-segment encoding only ever splits on the "/"
+// characters that came from the template itself.
+func expandPathTemplate(pattern string, vars map[string]any) (string, error) {
+	placeholders := findPlaceholders(pattern)
+	if len(placeholders) == 0 {
+		return pattern, nil
+	}
+
+	var missing []string
+	var b strings.Builder
+	b.Grow(len(pattern))
+
+	pos := 0
+	for _, ph := range placeholders {
+		b.WriteString(pattern[pos:ph.start])
+		pos = ph.end
+
+		value, ok := vars[ph.name]
+		if !ok {
+			missing = append(missing, ph.name)
+			b.WriteString(pattern[ph.start:ph.end])
+			continue
+		}
+
+		s := fmt.Sprint(value)
+		if ph.constraint != "" {
+			re, err := regexp.Compile("^(?:" + ph.constraint + ")$")
+			if err != nil {
+				return "", fmt.Errorf("urlbuilder: invalid regex constraint %q for placeholder %q: %w", ph.constraint, ph.name, err)
+			}
+			if !re.MatchString(s) {
+				return "", fmt.Errorf("urlbuilder: value %q for placeholder %q does not match constraint %q", s, ph.name, ph.constraint)
+			}
+		}
+		b.WriteString(encodePchar(s))
+	}
+	b.WriteString(pattern[pos:])
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("urlbuilder: missing value(s) for path template placeholder(s): %s", strings.Join(missing, ", "))
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,57 @@
+package urlbuilder
+
+import "sync"
+
+// urlBuilderPool recycles UrlBuilder instances, following the same
+// Acquire/Release pattern as fasthttp's AcquireURI/ReleaseURI, so high-QPS
+// callers (HTTP clients, logging middleware) can build URLs without
+// allocating a new UrlBuilder plus its path and query slices on every call.
+var urlBuilderPool = sync.Pool{
+	New: func() any {
+		return &UrlBuilder{
+			query: make([]query, 0, 3),
+			path:  make([]string, 0, 7),
+		}
+	},
+}
+
+// scratchBufPool holds reusable byte slices for BuildTo, so writing a URL
+// to an io.Writer doesn't need its own per-call allocation.
+var scratchBufPool = sync.Pool{
+	New: func() any {
+		buf := make([]byte, 0, 64)
+		return &buf
+	},
+}
+
+// AcquireURL returns a UrlBuilder from the pool, ready for use via UrlPart
+// options or the fluent setters. Callers must return it with ReleaseURL
+// once they are done building.
+func AcquireURL() *UrlBuilder {
+	return urlBuilderPool.Get().(*UrlBuilder)
+}
+
+// ReleaseURL resets ub and returns it to the pool. Do not use ub after
+// calling ReleaseURL.
+func ReleaseURL(ub *UrlBuilder) {
+	ub.Reset()
+	urlBuilderPool.Put(ub)
+}
+
+// Reset clears ub back to an empty builder while retaining the underlying
+// path and query slice capacity, so it can be returned to a pool and
+// reused without the next caller paying for fresh allocations.
+func (ub *UrlBuilder) Reset() {
+	ub.path = ub.path[:0]
+	ub.query = ub.query[:0]
+	ub.scheme = ""
+	ub.host = ""
+	ub.user = ""
+	ub.password = ""
+	ub.id = ""
+	ub.fragment = ""
+	ub.port = 0
+	ub.qmode = QModeLast
+	ub.err = nil
+	ub.endPathWithSlash = false
+}
@@ -2,6 +2,8 @@ package urlbuilder
 
 import (
 	"fmt"
+	"net/url"
+	"sort"
 	"strings"
 )
 
@@ -30,35 +32,29 @@ func (qs *QueryString) Build() string {
 	if len(qs.qrs) == 0 {
 		return ""
 	}
-	var b strings.Builder
-	first := true
-	if qs.mode == QModeLast || qs.mode == QModeError {
-		qmap := make(map[string]string)
-		for _, q := range qs.qrs {
-			if _, found := qmap[q.name]; found && qs.mode == QModeError {
-				qs.err = fmt.Errorf("duplicate query name found")
-				return ""
-			}
-			qmap[q.name] = q.value
+
+	pairs := qs.qrs
+	switch qs.mode {
+	case QModeLast, QModeError, QModeSortedLexical:
+		deduped, err := dedupeQueryLast(qs.qrs, qs.mode == QModeError)
+		if err != nil {
+			qs.err = err
+			return ""
 		}
-		for k, v := range qmap {
-			if !first {
-				b.WriteByte('&')
-			}
-			first = false
-			b.WriteString(k)
-			b.WriteByte('=')
-			b.WriteString(escape(v))
+		pairs = deduped
+		if qs.mode == QModeSortedLexical {
+			sort.Slice(pairs, func(i, j int) bool { return pairs[i].name < pairs[j].name })
 		}
-	} else {
-		for i, q := range qs.qrs {
-			if i > 0 {
-				b.WriteByte('&')
-			}
-			b.WriteString(q.name)
-			b.WriteByte('=')
-			b.WriteString(escape(q.value))
+	}
+
+	var b strings.Builder
+	for i, q := range pairs {
+		if i > 0 {
+			b.WriteByte('&')
 		}
+		b.WriteString(url.QueryEscape(q.name))
+		b.WriteByte('=')
+		b.WriteString(url.QueryEscape(q.value))
 	}
 	return b.String()
 }
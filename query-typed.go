@@ -0,0 +1,134 @@
+package urlbuilder
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"time"
+)
+
+// stringifyValue converts a query value into its string representation(s).
+// Slices expand into one string per element so QueryAdd, QuerySet and
+// Query can feed repeated parameters from a single call; everything else
+// yields a single-element slice. This is the shared conversion every query
+// entry point uses so they agree on how a given Go type is rendered.
+func stringifyValue(value any) []string {
+	switch v := value.(type) {
+	case nil:
+		return []string{""}
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case time.Time:
+		return []string{v.Format(time.RFC3339)}
+	case encoding.TextMarshaler:
+		b, err := v.MarshalText()
+		if err != nil {
+			return []string{""}
+		}
+		return []string{string(b)}
+	case fmt.Stringer:
+		return []string{v.String()}
+	}
+
+	if rv := reflect.ValueOf(value); rv.Kind() == reflect.Slice {
+		out := make([]string, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			out[i] = fmt.Sprint(rv.Index(i).Interface())
+		}
+		return out
+	}
+
+	return []string{fmt.Sprint(value)}
+}
+
+// querySet is the shared implementation behind the QuerySet UrlPart and
+// the fluent QuerySet method: it sets name to value, replacing any
+// existing entries for name.
+func (ub *UrlBuilder) querySet(name string, value any) {
+	if name == "" {
+		return
+	}
+	ub.queryDel(name)
+	ub.queryAdd(name, value)
+}
+
+// queryAdd is the shared implementation behind the QueryAdd UrlPart and
+// the fluent QueryAdd method: it appends value to name's existing
+// entries. A slice value (e.g. []string, []int) expands into one repeated
+// parameter per element.
+func (ub *UrlBuilder) queryAdd(name string, value any) {
+	if name == "" {
+		return
+	}
+	for _, v := range stringifyValue(value) {
+		ub.query = append(ub.query, query{name: name, value: v})
+	}
+}
+
+// queryDel is the shared implementation behind the QueryDel UrlPart and
+// the fluent QueryDel method: it removes every entry named name.
+func (ub *UrlBuilder) queryDel(name string) {
+	if len(ub.query) == 0 {
+		return
+	}
+	kept := ub.query[:0]
+	for _, q := range ub.query {
+		if q.name != name {
+			kept = append(kept, q)
+		}
+	}
+	ub.query = kept
+}
+
+// QuerySet sets name to value, replacing any existing entries for name.
+// It mirrors url.Values.Set, but accepts any of the typed values
+// stringifyValue understands instead of a bare string.
+func QuerySet(name string, value any) UrlPart {
+	return func(ub *UrlBuilder) error {
+		ub.querySet(name, value)
+		return nil
+	}
+}
+
+// QueryAdd appends value to name's existing entries, mirroring
+// url.Values.Add. A slice value (e.g. []string, []int) expands into one
+// repeated parameter per element.
+func QueryAdd(name string, value any) UrlPart {
+	return func(ub *UrlBuilder) error {
+		ub.queryAdd(name, value)
+		return nil
+	}
+}
+
+// QueryDel removes every entry named name, mirroring url.Values.Del.
+func QueryDel(name string) UrlPart {
+	return func(ub *UrlBuilder) error {
+		ub.queryDel(name)
+		return nil
+	}
+}
+
+// QueryHas reports whether any entry named name has been added, mirroring
+// url.Values.Has.
+func (ub *UrlBuilder) QueryHas(name string) bool {
+	for _, q := range ub.query {
+		if q.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Values returns the builder's current query parameters as a url.Values,
+// letting callers already working with net/url's Values type interoperate
+// with UrlBuilder instead of juggling the two representations by hand.
+func (ub *UrlBuilder) Values() url.Values {
+	v := make(url.Values, len(ub.query))
+	for _, q := range ub.query {
+		v[q.name] = append(v[q.name], q.value)
+	}
+	return v
+}
@@ -0,0 +1,161 @@
+package urlbuilder
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// defaultSchemePorts maps well-known schemes to the port they imply when
+// none is given explicitly, so Build can omit a redundant ":443" / ":80" /
+// etc. and parseHost can recognize when an explicit port is actually just
+// the default for its scheme. Unrecognized schemes have no entry, so a
+// caller-supplied port is always kept for them.
+var defaultSchemePorts = map[string]uint{
+	"https": 443,
+	"http":  80,
+	"ftp":   21,
+	"sftp":  22,
+}
+
+// parseHost resolves a raw host/URL string supplied to Host into its RFC
+// 3986 components. Unlike a bare net/url.Parse followed by naive port
+// stripping, it copes with bracketed IPv6 literals, userinfo, any scheme
+// (not just http and https), and path, query and fragment suffixes,
+// feeding every part it recognizes back into ub so the original URL can
+// round-trip through Build. It returns an error if raw is a genuinely
+// opaque URI (e.g. "sip:alice@example.com"), which this builder has no
+// field to hold since it only renders hierarchical scheme://host URLs.
+func (ub *UrlBuilder) parseHost(raw string) error {
+	raw = strings.ReplaceAll(raw, "\"", "/")
+
+	u, err := url.Parse(raw)
+	isHostPortMisparse := u != nil && u.Host == "" && (u.Scheme == "" || (u.Opaque != "" && isAllDigits(u.Opaque)))
+	if err != nil || isHostPortMisparse {
+		// Not a full URL (raw has no scheme, e.g. "api.example.com"), or
+		// net/url misread a bare "host:port" as a "scheme:opaque" pair whose
+		// opaque part is just a port number (e.g. "localhost:3000"): treat
+		// the whole string as host[:port].
+		host, port := splitHostPort(strings.TrimSuffix(raw, "/"))
+		ub.host = host
+		if port != 0 {
+			ub.port = port
+		}
+		return nil
+	}
+
+	if u.Host == "" && u.Opaque != "" {
+		// A genuine opaque URI, e.g. "sip:alice@example.com": this builder
+		// only supports hierarchical (scheme://host/...) URLs, so fail
+		// instead of stuffing the raw, scheme-qualified string into host.
+		return fmt.Errorf("urlbuilder: %q is an opaque URI, which this builder does not support", raw)
+	}
+
+	if u.Scheme != "" {
+		ub.scheme = strings.ToLower(u.Scheme)
+	}
+
+	if u.User != nil {
+		ub.user = u.User.Username()
+		if pwd, ok := u.User.Password(); ok {
+			ub.password = pwd
+		}
+	}
+
+	// u.Host may legitimately be empty here for a hierarchical URL with no
+	// authority, e.g. "file:///etc/passwd" (scheme "file", path
+	// "/etc/passwd"); leave ub.host unset in that case rather than guessing.
+	if u.Host != "" {
+		host, port := splitHostPort(u.Host)
+		ub.host = host
+		if port != 0 && port != defaultSchemePorts[ub.scheme] {
+			ub.port = port
+		}
+	}
+
+	if u.Path != "" && u.Path != "/" {
+		ub.path = append(ub.path, u.Path)
+	}
+
+	if u.RawQuery != "" {
+		ub.query = append(ub.query, parseQueryPairs(u.RawQuery)...)
+	}
+
+	if u.Fragment != "" {
+		ub.fragment = u.Fragment
+	}
+
+	return nil
+}
+
+// splitHostPort separates a host[:port] string into its parts using
+// net.SplitHostPort, so a bracketed IPv6 literal such as
+// "[2001:db8::1]:8443" is handled correctly instead of breaking on its
+// first colon.
+func splitHostPort(hostport string) (host string, port uint) {
+	h, p, err := net.SplitHostPort(hostport)
+	if err != nil {
+		return hostport, 0
+	}
+	n, err := strconv.ParseUint(p, 10, 32)
+	if err != nil {
+		return hostport, 0
+	}
+	return h, uint(n)
+}
+
+// isAllDigits reports whether s is non-empty and consists only of ASCII
+// digits, used to tell a net/url "scheme:opaque" misparse of a bare
+// "host:port" (opaque is the numeric port) apart from a genuinely opaque
+// URI such as "sip:alice@example.com".
+func isAllDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// formatHost renders host for output, re-adding the brackets an IPv6
+// literal needs around it so it isn't mistaken for a host:port separator.
+func formatHost(host string) string {
+	if strings.Contains(host, ":") && !strings.HasPrefix(host, "[") {
+		return "[" + host + "]"
+	}
+	return host
+}
+
+// parseQueryPairs splits a raw query string into ordered name/value pairs,
+// percent-decoding each half. Order is preserved (unlike url.ParseQuery,
+// which returns an unordered map) so callers that re-serialize through
+// Build keep a deterministic, input-matching layout.
+func parseQueryPairs(raw string) []query {
+	if raw == "" {
+		return nil
+	}
+	parts := strings.Split(raw, "&")
+	qs := make([]query, 0, len(parts))
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+		name, value := part, ""
+		if idx := strings.IndexByte(part, '='); idx != -1 {
+			name, value = part[:idx], part[idx+1:]
+		}
+		if n, err := url.QueryUnescape(name); err == nil {
+			name = n
+		}
+		if v, err := url.QueryUnescape(value); err == nil {
+			value = v
+		}
+		qs = append(qs, query{name: name, value: value})
+	}
+	return qs
+}
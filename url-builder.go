@@ -5,7 +5,9 @@ package urlbuilder
 
 import (
 	"fmt"
+	"io"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -61,20 +63,42 @@ const (
 
 	// QModeError triggers an error if duplicate query parameter names are detected.
 	QModeError
+
+	// QModeSortedLexical behaves like QModeLast (last value wins per name)
+	// but additionally sorts the output by name in lexical byte order, for
+	// canonical use cases such as AWS SigV4-style signed URLs where query
+	// parameters must be serialized in a fixed order.
+	QModeSortedLexical
 )
 
-// New creates a new UrlBuilder with the provided UrlPart modifiers.
+// New creates a new UrlBuilder with the provided UrlPart modifiers. Parts
+// are applied in order; if one returns an error, it is recorded on the
+// builder (retrievable via Err or BuildSafe) and the remaining parts are
+// skipped.
 func New(part ...UrlPart) *UrlBuilder {
 	ub := UrlBuilder{
 		query: make([]query, 0, 3), // initializing to a capacity minimizes reallocations
 		path:  make([]string, 0, 7),
 	}
 	for _, p := range part {
-		p(&ub)
+		ub.applyPart(p)
 	}
 	return &ub
 }
 
+// applyPart runs part against ub unless ub already carries an error,
+// recording any error part returns so the next part in the chain
+// short-circuits instead of silently running against an already-invalid
+// builder.
+func (ub *UrlBuilder) applyPart(part UrlPart) {
+	if ub.err != nil {
+		return
+	}
+	if err := part(ub); err != nil {
+		ub.err = err
+	}
+}
+
 // NewUrl returns a UrlBuilder with just a host.
 func NewUrl(host string, part ...UrlPart) *UrlBuilder {
 	up := make([]UrlPart, 0, 7)
@@ -107,68 +131,9 @@ func (ub *UrlBuilder) EndPathWithSlash(indeed bool) {
 	ub.endPathWithSlash = indeed
 }
 
-func (ub *UrlBuilder) getHostParts(host string) {
-	var (
-		scheme,
-		path string
-		port int
-	)
-
-	host = strings.ReplaceAll(host, "\"", "/")
-
-	// If the host was supplied with a valid url and it has parts, take its result
-	// Note:
-	// 	Only scheme, host, port and path are recognized.
-	// 	A segment after the first slash will be considered a path
-	if r, err := url.Parse(host); err == nil {
-		if r.Host != "" {
-			host = r.Host
-			if idx := strings.Index(host, ":"); idx != -1 {
-				host = host[:idx] // Modify host
-			}
-		}
-		// If it has scheme, this is not a pure host, so flag false
-		if r.Scheme == "http" || r.Scheme == "https" {
-			scheme = r.Scheme
-		}
-
-		// If it has port other than what is standard, flag false
-		port, _ = strconv.Atoi(r.Port())
-		if port != 0 {
-			if scheme == "http" && port == 80 || scheme == "https" && port == 443 {
-				port = 0
-			}
-		}
-		// If it has a path, it is not a pure host, flag false
-		if r.Path != "" && r.Host != "" {
-			path = r.Path
-
-			// If path is just a /, remove it
-			if path == "/" {
-				path = ""
-			}
-		}
-	}
-
-	// Additional stripping of port
-	if idx := strings.Index(host, ":"); idx != -1 {
-		pvhost := host
-		host = pvhost[:idx]
-		port, _ = strconv.Atoi(pvhost[idx+1:])
-	}
-	ub.host, _ = strings.CutSuffix(host, "/")
-	if port != 0 {
-		ub.port = uint(port)
-	}
-	if scheme != "" {
-		ub.scheme = scheme
-	}
-	if path != "" {
-		ub.path = append(ub.path, path)
-	}
-}
-
-// Clone returns a new UrlBuilder copied from an existing one and applies additional UrlParts.
+// Clone returns a new UrlBuilder copied from an existing one and applies
+// additional UrlParts. As with New, a part that returns an error is
+// recorded on the clone and short-circuits the remaining parts.
 func Clone(ub *UrlBuilder, part ...UrlPart) *UrlBuilder {
 	cloneUb := *ub
 
@@ -181,29 +146,43 @@ func Clone(ub *UrlBuilder, part ...UrlPart) *UrlBuilder {
 	}
 
 	for _, p := range part {
-		p(&cloneUb)
+		cloneUb.applyPart(p)
 	}
 	return &cloneUb
 }
 
-// Sch sets the scheme (e.g., "http", "https") of the URL.
+// Sch sets the scheme (e.g., "http", "https") of the URL. It returns an
+// error if sch contains characters RFC 3986 does not allow in a scheme.
 func Sch(sch string) UrlPart {
 	return func(ub *UrlBuilder) error {
 		if sch == "" {
 			return nil
 		}
+		if !validSchemeChars(sch) {
+			return fmt.Errorf("urlbuilder: invalid scheme %q", sch)
+		}
 		ub.scheme = sch
 		return nil
 	}
 }
 
-// Host sets the host (domain or IP) of the URL.
+// Host sets the host (domain or IP) of the URL, along with any scheme,
+// userinfo, port, path, query or fragment embedded in h. It returns an
+// error if the resolved host contains control characters.
 func Host(h string) UrlPart {
 	return func(ub *UrlBuilder) error {
 		if h == "" {
 			return nil
 		}
-		ub.getHostParts(h)
+		if err := ub.parseHost(h); err != nil {
+			return err
+		}
+		if hasControlChar(ub.host) {
+			return fmt.Errorf("urlbuilder: host %q contains control characters", ub.host)
+		}
+		if ub.port > 65535 {
+			return fmt.Errorf("urlbuilder: port %d exceeds maximum of 65535", ub.port)
+		}
 		return nil
 	}
 }
@@ -264,12 +243,16 @@ func ID(id any) UrlPart {
 	}
 }
 
-// Port sets the port number of the URL.
+// Port sets the port number of the URL. It returns an error if port
+// exceeds the maximum valid TCP port, 65535.
 func Port(port uint) UrlPart {
 	return func(ub *UrlBuilder) error {
 		if port == 0 {
 			return nil
 		}
+		if port > 65535 {
+			return fmt.Errorf("urlbuilder: port %d exceeds maximum of 65535", port)
+		}
 		ub.port = port
 		return nil
 	}
@@ -291,24 +274,13 @@ func EndPathWithSlash(indeed bool) UrlPart {
 	}
 }
 
-// Query appends a query parameter to the URL.
+// Query appends a query parameter to the URL. value may be a plain string
+// or any of the typed values QueryAdd accepts (slices, time.Time,
+// fmt.Stringer, encoding.TextMarshaler); a slice expands into one repeated
+// parameter per element.
 func Query(name string, value any) UrlPart {
 	return func(ub *UrlBuilder) error {
-		if name == "" {
-			return nil
-		}
-		v := fmt.Sprint(value)
-		// Check for values that may have the same name and value
-		// If the keys and values are the same as the one being added,
-		// ignore
-		if ub.qmode == QModeArray {
-			for _, kv := range ub.query {
-				if strings.EqualFold(kv.name, name) && strings.EqualFold(kv.value, v) {
-					continue
-				}
-			}
-		}
-		ub.query = append(ub.query, query{name: name, value: v})
+		ub.queryAdd(name, value)
 		return nil
 	}
 }
@@ -324,8 +296,9 @@ func Frag(f string) UrlPart {
 	}
 }
 
-// Build constructs the URL as a string. Returns an empty string if an error occurred.
-func (ub *UrlBuilder) Build() string {
+// normalized returns a value copy of ub with scheme, host and port
+// defaults applied, ready to be rendered by appendURL.
+func (ub *UrlBuilder) normalized() UrlBuilder {
 	cb := *ub
 
 	if cb.scheme == "" {
@@ -337,35 +310,35 @@ func (ub *UrlBuilder) Build() string {
 		cb.host = "localhost"
 	}
 
-	switch cb.scheme {
-	case "https":
-		if cb.port == 0 {
-			cb.port = 443
-		}
-	case "http":
-		if cb.port == 0 {
-			cb.port = 80
-		}
+	if cb.port == 0 {
+		cb.port = defaultSchemePorts[cb.scheme]
 	}
 
-	var b strings.Builder
+	return cb
+}
 
-	b.WriteString(cb.scheme)
-	b.WriteString("://")
+// appendURL renders cb and appends it to dst, returning the extended slice.
+// It is the single code path shared by Build, BuildTo and AppendURL. On a
+// duplicate query name under QModeError, it returns dst unchanged along
+// with the error.
+func appendURL(cb UrlBuilder, dst []byte) ([]byte, error) {
+	start := len(dst)
+	dst = append(dst, cb.scheme...)
+	dst = append(dst, "://"...)
 
 	if cb.user != "" {
-		b.WriteString(cb.user)
+		dst = append(dst, cb.user...)
 		if cb.password != "" {
-			b.WriteByte(':')
-			b.WriteString(cb.password)
+			dst = append(dst, ':')
+			dst = append(dst, cb.password...)
 		}
-		b.WriteByte('@')
+		dst = append(dst, '@')
 	}
 
-	b.WriteString(cb.host)
-	if !((cb.scheme == "http" && cb.port == 80) || (cb.scheme == "https" && cb.port == 443)) {
-		b.WriteByte(':')
-		b.WriteString(strconv.Itoa(int(cb.port)))
+	dst = append(dst, formatHost(cb.host)...)
+	if cb.port != 0 && cb.port != defaultSchemePorts[cb.scheme] {
+		dst = append(dst, ':')
+		dst = strconv.AppendUint(dst, uint64(cb.port), 10)
 	}
 
 	pathTerminated := false
@@ -375,18 +348,18 @@ func (ub *UrlBuilder) Build() string {
 			if segment == "" {
 				continue
 			}
-			b.WriteByte('/')
+			dst = append(dst, '/')
 			segment = strings.ReplaceAll(segment, "\"", "/")
 			segment, _ = strings.CutPrefix(segment, "/")
 			segment, _ = strings.CutSuffix(segment, "/")
 			if segment != "" {
-				b.WriteString(segment)
+				dst = appendEncodedPathSegment(dst, segment)
 			}
 		}
 	}
 
 	// Will generally check if the string so far has a forward slash
-	if chkStr := b.String(); strings.HasSuffix(chkStr, "/") {
+	if len(dst) > 0 && dst[len(dst)-1] == '/' {
 		pathTerminated = true
 	}
 
@@ -395,10 +368,10 @@ func (ub *UrlBuilder) Build() string {
 		// It will not set the flag to pathTerminated to true
 		// because it shouldn't be terminated with slash
 		if !pathTerminated {
-			b.WriteByte('/')
+			dst = append(dst, '/')
 			pathTerminated = true
 		}
-		b.WriteString(cb.id)
+		dst = append(dst, cb.id...)
 	}
 
 	if len(cb.query) > 0 {
@@ -406,56 +379,104 @@ func (ub *UrlBuilder) Build() string {
 		// If this wasn't terminated and it should be,
 		// terminate it
 		if !pathTerminated && cb.endPathWithSlash {
-			b.WriteByte('/')
+			dst = append(dst, '/')
 			pathTerminated = true
 		}
-		b.WriteByte('?')
-
-		first := true
-		if cb.qmode == QModeLast || cb.qmode == QModeError {
-			qmap := make(map[string]string)
-			for _, q := range cb.query {
-				if _, found := qmap[q.name]; found && cb.qmode == QModeError {
-					ub.err = fmt.Errorf("duplicate query name found")
-					return ""
-				}
-				qmap[q.name] = q.value
+		dst = append(dst, '?')
+
+		pairs := cb.query
+		switch cb.qmode {
+		case QModeLast, QModeError, QModeSortedLexical:
+			deduped, err := dedupeQueryLast(cb.query, cb.qmode == QModeError)
+			if err != nil {
+				return dst[:start], err
 			}
-			for k, v := range qmap {
-				if !first {
-					b.WriteByte('&')
-				}
-				first = false
-				b.WriteString(k)
-				b.WriteByte('=')
-				b.WriteString(url.QueryEscape(v))
+			pairs = deduped
+			if cb.qmode == QModeSortedLexical {
+				sort.Slice(pairs, func(i, j int) bool { return pairs[i].name < pairs[j].name })
 			}
-		} else {
-			for i, q := range cb.query {
-				if i > 0 {
-					b.WriteByte('&')
-				}
-				b.WriteString(q.name)
-				b.WriteByte('=')
-				b.WriteString(url.QueryEscape(q.value))
+		}
+
+		for i, q := range pairs {
+			if i > 0 {
+				dst = append(dst, '&')
 			}
+			dst = append(dst, url.QueryEscape(q.name)...)
+			dst = append(dst, '=')
+			dst = append(dst, url.QueryEscape(q.value)...)
 		}
 	}
 
 	if cb.fragment != "" {
 		if !pathTerminated && cb.endPathWithSlash {
-			b.WriteByte('/')
+			dst = append(dst, '/')
 			pathTerminated = true
 		}
-		b.WriteByte('#')
-		b.WriteString(cb.fragment)
+		dst = append(dst, '#')
+		dst = append(dst, cb.fragment...)
 	}
 
 	if !pathTerminated && cb.endPathWithSlash {
-		b.WriteByte('/')
+		dst = append(dst, '/')
+	}
+
+	return dst, nil
+}
+
+// Build constructs the URL as a string. Returns an empty string if an
+// error occurred, whether from this call or from an earlier UrlPart that
+// New/Clone already recorded on ub.
+func (ub *UrlBuilder) Build() string {
+	if ub.err != nil {
+		return ""
+	}
+	buf, err := appendURL(ub.normalized(), make([]byte, 0, 64))
+	if err != nil {
+		ub.err = err
+		return ""
+	}
+	return string(buf)
+}
+
+// AppendURL appends the built URL to dst and returns the extended slice,
+// in the style of fasthttp's AppendBytes helpers. Passing a dst with
+// enough spare capacity lets repeated calls build URLs with zero
+// allocations. On error — including one already recorded on ub by an
+// earlier UrlPart — dst is returned unchanged and the error is recorded,
+// retrievable via Err or BuildSafe.
+func (ub *UrlBuilder) AppendURL(dst []byte) []byte {
+	if ub.err != nil {
+		return dst
 	}
+	out, err := appendURL(ub.normalized(), dst)
+	if err != nil {
+		ub.err = err
+		return dst
+	}
+	return out
+}
 
-	return b.String()
+// BuildTo writes the built URL directly to w, returning the number of
+// bytes written. It avoids the intermediate string allocation that Build
+// incurs, using a pooled scratch buffer internally.
+func (ub *UrlBuilder) BuildTo(w io.Writer) (int, error) {
+	if ub.err != nil {
+		return 0, ub.err
+	}
+
+	bp := scratchBufPool.Get().(*[]byte)
+	buf := (*bp)[:0]
+	defer func() {
+		*bp = buf
+		scratchBufPool.Put(bp)
+	}()
+
+	buf, err := appendURL(ub.normalized(), buf)
+	if err != nil {
+		ub.err = err
+		return 0, err
+	}
+	return w.Write(buf)
 }
 
 // BuildSafe constructs the URL and returns it with an error, if any.
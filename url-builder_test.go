@@ -1,6 +1,10 @@
 package urlbuilder
 
-import "testing"
+import (
+	"strings"
+	"testing"
+	"time"
+)
 
 func TestBuild(t *testing.T) {
 	ub := New(Port(5666))
@@ -47,6 +51,34 @@ func TestBuild(t *testing.T) {
 	ub10 := NewUrlWithPath("localhost:3000", "", Path("/grpperm/"))
 	t.Logf("Host with port and blank first path: %s", ub10.Build())
 
+	rt1 := "https://u:p@[2001:db8::1]:8443/a/b?x=1#frag"
+	ub11 := NewUrl(rt1)
+	if got := ub11.Build(); got != rt1 {
+		t.Fatalf("IPv6 host, userinfo, query and fragment did not round-trip: got %q, want %q", got, rt1)
+	}
+	t.Logf("IPv6 host, userinfo, query and fragment round-trip: %s", ub11.Build())
+
+	rt2 := "sftp://files.example.com/drop"
+	ub12 := NewUrl(rt2)
+	if got := ub12.Build(); got != rt2 {
+		t.Fatalf("non-http scheme did not round-trip: got %q, want %q", got, rt2)
+	}
+	t.Logf("Non-http scheme: %s", ub12.Build())
+
+	// A hierarchical URL with an empty authority keeps its scheme and path
+	// instead of being treated as a literal host string.
+	ub13 := NewUrl("file:///etc/passwd")
+	if want := "file://localhost/etc/passwd"; ub13.Build() != want {
+		t.Fatalf("file URL with empty authority: got %q, want %q", ub13.Build(), want)
+	}
+	t.Logf("File URL with empty authority: %s", ub13.Build())
+
+	// A genuinely opaque URI (no "//" authority at all) isn't something this
+	// builder can render, so Host must report an error instead of mangling
+	// it into a bracketed host.
+	if ub14 := NewUrl("sip:alice@example.com"); ub14.Err() == nil {
+		t.Fatalf("expected an error for an opaque URI, got %q", ub14.Build())
+	}
 }
 
 func TestSingle(t *testing.T) {
@@ -79,12 +111,190 @@ func TestQueryStringBuild(t *testing.T) {
 	t.Logf("Query string build: %s", qs.Build())
 }
 
+func TestTypedQuery(t *testing.T) {
+	ub := New(Host("example.com"), Mode(QModeArray))
+	ub.QueryAdd("tag", []string{"go", "url"})
+	ub.QueryAdd("page", 2)
+	ub.QueryAdd("active", true)
+	ub.QueryAdd("created", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	t.Logf("Typed query add: %s", ub.Build())
+
+	ub.QuerySet("page", 3)
+	t.Logf("After QuerySet page=3: %s", ub.Build())
+
+	if !ub.QueryHas("page") {
+		t.Fatalf("expected QueryHas(page) to be true")
+	}
+
+	ub.QueryDel("tag")
+	if ub.QueryHas("tag") {
+		t.Fatalf("expected QueryHas(tag) to be false after QueryDel")
+	}
+	t.Logf("After QueryDel tag: %s", ub.Build())
+
+	t.Logf("Values(): %#v", ub.Values())
+}
+
+func TestDeterministicQueryEncoding(t *testing.T) {
+	build := func() string {
+		return New(
+			Host("example.com"),
+			Path("search result"),
+			Query("q", "golang url#1"),
+			Query("page", 2),
+			Query("sort", "desc"),
+		).Build()
+	}
+
+	want := build()
+	for i := 0; i < 10; i++ {
+		if got := build(); got != want {
+			t.Fatalf("QModeLast output not deterministic: got %q, want %q", got, want)
+		}
+	}
+	t.Logf("Deterministic QModeLast: %s", want)
+
+	sorted := New(
+		Host("example.com"),
+		Path("a"),
+		Query("z", 1),
+		Query("a", 2),
+		Query("m", 3),
+		Mode(QModeSortedLexical),
+	).Build()
+	t.Logf("QModeSortedLexical: %s", sorted)
+}
+
+func TestFluentBuilderAndValidate(t *testing.T) {
+	u, err := New().Host("example.com").Path("retrieve").Query("un", "zaldy").ID(1).Validate().BuildSafe()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Logf("Fluent build: %s", u)
+
+	if _, err := New().Sch("ht!tp").Host("example.com").Validate().BuildSafe(); err == nil {
+		t.Fatalf("expected an error for an invalid scheme")
+	}
+
+	if _, err := New().Port(70000).Host("example.com").BuildSafe(); err == nil {
+		t.Fatalf("expected an error for an out-of-range port")
+	}
+
+	// Later parts should be skipped once an earlier one fails.
+	ub := New(Sch("bad scheme"), Path("should-not-be-added"))
+	if ub.Err() == nil {
+		t.Fatalf("expected New to capture the Sch error")
+	}
+	if len(ub.path) != 0 {
+		t.Fatalf("expected Path to be skipped after Sch failed, got %v", ub.path)
+	}
+
+	// Build, AppendURL and BuildTo must all refuse to render a builder that
+	// already carries an error, instead of silently building whatever
+	// partial state was assembled before the failing part.
+	if got := ub.Build(); got != "" {
+		t.Fatalf("expected Build to return \"\" once ub.err is set, got %q", got)
+	}
+	if got := ub.AppendURL([]byte("prefix")); string(got) != "prefix" {
+		t.Fatalf("expected AppendURL to leave dst untouched once ub.err is set, got %q", got)
+	}
+	var sb strings.Builder
+	if n, err := ub.BuildTo(&sb); err == nil || n != 0 || sb.Len() != 0 {
+		t.Fatalf("expected BuildTo to fail once ub.err is set, got n=%d err=%v buf=%q", n, err, sb.String())
+	}
+}
+
+func TestPathTemplate(t *testing.T) {
+	u, err := New(
+		Host("api.example.com"),
+		PathTemplate("/users/{userID}/orders/{orderID:[0-9]+}", map[string]any{
+			"userID":  "zaldy baguinon",
+			"orderID": 42,
+		}),
+	).BuildSafe()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Logf("Path template: %s", u)
+
+	if _, err := New(
+		Host("api.example.com"),
+		PathTemplate("/orders/{orderID:[0-9]+}", map[string]any{"orderID": "not-a-number"}),
+	).BuildSafe(); err == nil {
+		t.Fatalf("expected an error when the value fails its regex constraint")
+	}
+
+	if _, err := New(
+		Host("api.example.com"),
+		PathTemplate("/orders/{orderID}", map[string]any{}),
+	).BuildSafe(); err == nil {
+		t.Fatalf("expected an error for a missing placeholder value")
+	}
+
+	u2 := NewUrl("api.example.com", MustPathTemplate("/users/{userID}", map[string]any{"userID": 7})).Build()
+	t.Logf("Must path template: %s", u2)
+
+	// A placeholder value containing "/" must not inject an extra path
+	// segment: it should come out as a literal %2F, not a second "/".
+	u3 := NewUrl("api.example.com", PathTemplate("/users/{userID}/profile", map[string]any{"userID": "a/b"})).Build()
+	if want := "https://api.example.com/users/a%2Fb/profile"; u3 != want {
+		t.Fatalf("expected embedded slash in placeholder value to be escaped: got %q, want %q", u3, want)
+	}
+	t.Logf("Path template with embedded slash: %s", u3)
+
+	// A constraint regex containing its own braces (a quantifier like
+	// "{3}") must still parse as one placeholder, not split at the inner
+	// "}".
+	u4, err := New(
+		Host("api.example.com"),
+		PathTemplate("/orders/{orderID:[0-9]{3}}", map[string]any{"orderID": "123"}),
+	).BuildSafe()
+	if err != nil {
+		t.Fatalf("unexpected error with a braced constraint quantifier: %v", err)
+	}
+	if want := "https://api.example.com/orders/123"; u4 != want {
+		t.Fatalf("braced constraint quantifier: got %q, want %q", u4, want)
+	}
+	t.Logf("Path template with braced constraint quantifier: %s", u4)
+
+	if _, err := New(
+		Host("api.example.com"),
+		PathTemplate("/orders/{orderID:[0-9]{3}}", map[string]any{"orderID": "12"}),
+	).BuildSafe(); err == nil {
+		t.Fatalf("expected an error when the value fails a braced constraint quantifier")
+	}
+}
+
 func BenchmarkSimpleURL(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		NewUrlWithPath("example.com", "api/v1/users").Build()
 	}
 }
 
+// BenchmarkAcquireReleaseURL shows the pooled construction path: acquiring
+// a builder, populating it by calling UrlPart options directly on it
+// instead of going through New, and releasing it back to the pool.
+func BenchmarkAcquireReleaseURL(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		ub := AcquireURL()
+		Host("example.com")(ub)
+		Path("api/v1/users")(ub)
+		ub.Build()
+		ReleaseURL(ub)
+	}
+}
+
+// BenchmarkAppendURL reuses the same destination buffer across iterations
+// to demonstrate the zero-alloc-per-call path AppendURL is meant for.
+func BenchmarkAppendURL(b *testing.B) {
+	ub := NewUrlWithPath("example.com", "api/v1/users")
+	buf := make([]byte, 0, 64)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf = ub.AppendURL(buf[:0])
+	}
+}
+
 func BenchmarkURLWithID(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		NewUrlWithID("example.com", "api/v1/users", 12345).Build()
@@ -0,0 +1,32 @@
+package urlbuilder
+
+// validSchemeChars reports whether sch is a syntactically valid RFC 3986
+// scheme: a letter, followed by any number of letters, digits, "+", "-"
+// or ".".
+func validSchemeChars(sch string) bool {
+	if sch == "" {
+		return false
+	}
+	for i := 0; i < len(sch); i++ {
+		c := sch[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+		case '0' <= c && c <= '9' && i > 0:
+		case (c == '+' || c == '-' || c == '.') && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// hasControlChar reports whether s contains any ASCII control character
+// (0x00-0x1F or 0x7F), which is never valid in a URL host.
+func hasControlChar(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] == 0x7F {
+			return true
+		}
+	}
+	return false
+}